@@ -0,0 +1,145 @@
+/*
+ * Copyright (C) 2020 Nicolas SCHWARTZ
+ *
+ * This library is free software; you can redistribute it and/or
+ * modify it under the terms of the GNU Lesser General Public
+ * License as published by the Free Software Foundation; either
+ * version 2 of the License, or (at your option) any later version.
+ *
+ * This library is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the GNU
+ * Lesser General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public
+ * License along with this library; if not, write to the Free Software
+ * Foundation, Inc., 51 Franklin St, Fifth Floor, Boston, MA 02110-1301, USA
+ */
+
+// Package events defines the pod state change notifications that flow
+// out of the message parsers and into anything watching the pod (a UI,
+// a logger, a test harness). It is a small pub/sub layer: producers call
+// Hub.Emit, consumers read from the channel returned by Hub.Subscribe.
+package events
+
+// Kind identifies the concrete type of an Event without requiring a
+// type switch, so subscribers can filter cheaply.
+type Kind int
+
+const (
+    KindActiveChanged Kind = iota
+    KindParameterChanged
+    KindTypeChanged
+    KindPresetLoaded
+    KindPresetChanged
+    KindSetChanged
+    KindWarning
+)
+
+func (k Kind) String() string {
+    switch k {
+    case KindActiveChanged:
+        return "ActiveChanged"
+    case KindParameterChanged:
+        return "ParameterChanged"
+    case KindTypeChanged:
+        return "TypeChanged"
+    case KindPresetLoaded:
+        return "PresetLoaded"
+    case KindPresetChanged:
+        return "PresetChanged"
+    case KindSetChanged:
+        return "SetChanged"
+    case KindWarning:
+        return "Warning"
+    }
+    return "Unknown"
+}
+
+// ParamField says which of a parameter's three bound values changed.
+type ParamField int
+
+const (
+    ParamCur ParamField = iota
+    ParamMin
+    ParamMax
+)
+
+// Event is implemented by every concrete event type emitted on the hub.
+type Event interface {
+    Kind() Kind
+}
+
+// ItemScoped is implemented by events that relate to a single
+// pedalboard item, so the hub can filter a subscription by ItemID
+// without the subscriber type-asserting every concrete event.
+type ItemScoped interface {
+    Event
+    TargetItem() uint32
+}
+
+// ActiveChanged is emitted when a pedalboard item is turned on or off.
+type ActiveChanged struct {
+    ItemID uint32
+    Active bool
+}
+
+func (ActiveChanged) Kind() Kind           { return KindActiveChanged }
+func (e ActiveChanged) TargetItem() uint32 { return e.ItemID }
+
+// ParameterChanged is emitted when a parameter's current, min or max
+// bound value is updated.
+type ParameterChanged struct {
+    ItemID  uint32
+    ParamID uint32
+    Field   ParamField
+    Value   [4]byte
+}
+
+func (ParameterChanged) Kind() Kind           { return KindParameterChanged }
+func (e ParameterChanged) TargetItem() uint32 { return e.ItemID }
+
+// TypeChanged is emitted when a pedalboard item's model is swapped.
+type TypeChanged struct {
+    ItemID uint32
+    Type   uint32
+}
+
+func (TypeChanged) Kind() Kind           { return KindTypeChanged }
+func (e TypeChanged) TargetItem() uint32 { return e.ItemID }
+
+// PresetLoaded is emitted once a PresetLoad message has been fully
+// parsed into the current preset, signalling that every pedalboard
+// item, cab, DT and setup parameter it touched has reached its final
+// value and any dependent UI can safely re-render in one pass.
+type PresetLoaded struct{}
+
+func (PresetLoaded) Kind() Kind { return KindPresetLoaded }
+
+// PresetChanged is emitted when the device switches its active preset.
+type PresetChanged struct {
+    Index uint8
+}
+
+func (PresetChanged) Kind() Kind { return KindPresetChanged }
+
+// SetChanged is emitted when the device switches its active set.
+type SetChanged struct {
+    Index uint8
+}
+
+func (SetChanged) Kind() Kind { return KindSetChanged }
+
+// Warning is emitted for diagnostics that previously only reached
+// log.Printf, such as an unknown parameter ID or a firmware layout the
+// parser doesn't recognise, so calling code can collect them instead of
+// losing them to stderr.
+type Warning struct {
+    Message string
+    ItemID  uint32
+    ParamID uint32
+    Err     error
+}
+
+func (Warning) Kind() Kind           { return KindWarning }
+func (e Warning) TargetItem() uint32 { return e.ItemID }
@@ -0,0 +1,231 @@
+/*
+ * Copyright (C) 2020 Nicolas SCHWARTZ
+ *
+ * This library is free software; you can redistribute it and/or
+ * modify it under the terms of the GNU Lesser General Public
+ * License as published by the Free Software Foundation; either
+ * version 2 of the License, or (at your option) any later version.
+ *
+ * This library is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the GNU
+ * Lesser General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public
+ * License along with this library; if not, write to the Free Software
+ * Foundation, Inc., 51 Franklin St, Fifth Floor, Boston, MA 02110-1301, USA
+ */
+
+package events
+
+import "sync"
+
+// defaultBufferSize is used by Subscribe when no WithBuffer option is
+// given. It leaves a consumer room for a burst of events, such as the
+// Warning events and the final PresetLoaded that one PresetLoad can
+// emit, before Emit starts dropping its events (or, for a
+// WithBackpressure subscriber, blocking).
+const defaultBufferSize = 64
+
+// EventSource is the read side of a subscription. C is closed when the
+// hub is closed or the subscription is closed.
+type EventSource struct {
+    C <-chan Event
+
+    hub *Hub
+    sub *subscription
+}
+
+// Close unsubscribes, releasing the hub's reference to this
+// subscriber. It is safe to call more than once.
+func (s EventSource) Close() {
+    s.hub.unsubscribe(s.sub)
+}
+
+// Dropped returns the number of events that were discarded for this
+// subscriber because its buffer was full and it was not subscribed
+// with WithBackpressure.
+func (s EventSource) Dropped() uint64 {
+    s.sub.mu.Lock()
+    defer s.sub.mu.Unlock()
+    return s.sub.dropped
+}
+
+type subscription struct {
+    c            chan Event
+    kinds        map[Kind]struct{}
+    itemID       *uint32
+    backpressure bool
+
+    // done is closed to force-unblock a pending backpressured send in
+    // Emit, so a stalled consumer can't wedge Close or its own
+    // unsubscribe forever. closeDone guards against closing it twice.
+    done      chan struct{}
+    closeDone sync.Once
+
+    mu      sync.Mutex
+    dropped uint64
+}
+
+func (s *subscription) wants(e Event) bool {
+    if s.kinds != nil {
+        if _, ok := s.kinds[e.Kind()]; !ok {
+            return false
+        }
+    }
+    if s.itemID != nil {
+        scoped, ok := e.(ItemScoped)
+        if !ok || scoped.TargetItem() != *s.itemID {
+            return false
+        }
+    }
+    return true
+}
+
+// Option configures a subscription created by Hub.Subscribe.
+type Option func(*subscription)
+
+// WithBuffer sets the per-subscriber channel buffer size.
+func WithBuffer(n int) Option {
+    return func(s *subscription) { s.c = make(chan Event, n) }
+}
+
+// WithKinds restricts a subscription to the given event kinds. Without
+// it, a subscriber receives every kind.
+func WithKinds(kinds ...Kind) Option {
+    return func(s *subscription) {
+        s.kinds = make(map[Kind]struct{}, len(kinds))
+        for _, k := range kinds {
+            s.kinds[k] = struct{}{}
+        }
+    }
+}
+
+// WithItemID restricts a subscription to events targeting a single
+// pedalboard item ID (events that aren't ItemScoped are filtered out).
+func WithItemID(id uint32) Option {
+    return func(s *subscription) { s.itemID = &id }
+}
+
+// WithBackpressure makes Emit block until this subscriber has room
+// instead of dropping the event when its buffer is full. Use sparingly:
+// a slow, backpressured subscriber stalls every producer on the hub
+// until it drains, unsubscribes (EventSource.Close) or the hub is shut
+// down (Hub.Close) - all three release any Emit blocked on it.
+func WithBackpressure() Option {
+    return func(s *subscription) { s.backpressure = true }
+}
+
+// Hub is a concurrency-safe fan-out of pod state change events. The USB
+// read goroutine (or any other producer) calls Emit; any number of
+// consumers call Subscribe to get their own buffered EventSource.
+type Hub struct {
+    mu     sync.RWMutex
+    subs   map[*subscription]struct{}
+    closed bool
+}
+
+// NewHub returns a ready to use, empty Hub.
+func NewHub() *Hub {
+    return &Hub{subs: make(map[*subscription]struct{})}
+}
+
+// Subscribe registers a new consumer and returns its EventSource. The
+// subscription stays open until EventSource.Close or Hub.Close is
+// called.
+func (h *Hub) Subscribe(opts ...Option) EventSource {
+    sub := &subscription{done: make(chan struct{})}
+    for _, opt := range opts {
+        opt(sub)
+    }
+    if sub.c == nil {
+        sub.c = make(chan Event, defaultBufferSize)
+    }
+
+    h.mu.Lock()
+    defer h.mu.Unlock()
+    if h.closed {
+        close(sub.c)
+        return EventSource{C: sub.c, hub: h, sub: sub}
+    }
+    h.subs[sub] = struct{}{}
+    return EventSource{C: sub.c, hub: h, sub: sub}
+}
+
+// Emit delivers e to every subscriber that wants it. Subscribers
+// without WithBackpressure never block the caller: if their buffer is
+// full the event is dropped and their Dropped counter is incremented.
+// A WithBackpressure subscriber blocks Emit until it has room or its
+// subscription is torn down (Close or EventSource.Close), whichever
+// comes first; it never blocks Emit past that point, since doing so
+// would also wedge Close and Subscribe, which share h.mu with Emit.
+func (h *Hub) Emit(e Event) {
+    h.mu.RLock()
+    defer h.mu.RUnlock()
+    if h.closed {
+        return
+    }
+    for sub := range h.subs {
+        if !sub.wants(e) {
+            continue
+        }
+        if sub.backpressure {
+            select {
+            case sub.c <- e:
+            case <-sub.done:
+            }
+            continue
+        }
+        select {
+        case sub.c <- e:
+        default:
+            sub.mu.Lock()
+            sub.dropped++
+            sub.mu.Unlock()
+        }
+    }
+}
+
+// Close shuts the hub down, closing every subscriber's channel. Emit
+// becomes a no-op and Subscribe returns an already-closed EventSource.
+// Any Emit currently blocked on a backpressured subscriber is released
+// first, so a stalled consumer can't wedge Close forever.
+func (h *Hub) Close() {
+    h.mu.RLock()
+    if h.closed {
+        h.mu.RUnlock()
+        return
+    }
+    for sub := range h.subs {
+        sub.closeDone.Do(func() { close(sub.done) })
+    }
+    h.mu.RUnlock()
+
+    h.mu.Lock()
+    defer h.mu.Unlock()
+    if h.closed {
+        return
+    }
+    h.closed = true
+    for sub := range h.subs {
+        close(sub.c)
+    }
+    h.subs = make(map[*subscription]struct{})
+}
+
+// unsubscribe removes sub and closes its channel. It closes sub.done
+// first, so if sub itself is the target of a blocked backpressured
+// Emit, that send is released instead of being stuck until Close. A
+// different subscriber stalled on backpressure can still delay this
+// call, the same way it would delay Close or Subscribe.
+func (h *Hub) unsubscribe(sub *subscription) {
+    sub.closeDone.Do(func() { close(sub.done) })
+
+    h.mu.Lock()
+    defer h.mu.Unlock()
+    if _, ok := h.subs[sub]; !ok {
+        return
+    }
+    delete(h.subs, sub)
+    close(sub.c)
+}
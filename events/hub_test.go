@@ -0,0 +1,82 @@
+/*
+ * Copyright (C) 2020 Nicolas SCHWARTZ
+ *
+ * This library is free software; you can redistribute it and/or
+ * modify it under the terms of the GNU Lesser General Public
+ * License as published by the Free Software Foundation; either
+ * version 2 of the License, or (at your option) any later version.
+ *
+ * This library is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the GNU
+ * Lesser General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public
+ * License along with this library; if not, write to the Free Software
+ * Foundation, Inc., 51 Franklin St, Fifth Floor, Boston, MA 02110-1301, USA
+ */
+
+package events
+
+import "testing"
+import "time"
+
+// TestCloseReleasesStalledBackpressure covers the bug fixed in 5060ea4:
+// a WithBackpressure subscriber that never drains its channel used to
+// wedge Close forever, because Emit held the hub's read lock for the
+// whole blocked send while Close waited on the write lock. Close must
+// now return promptly regardless.
+func TestCloseReleasesStalledBackpressure(t *testing.T) {
+    hub := NewHub()
+    hub.Subscribe(WithBuffer(1), WithBackpressure())
+
+    go func() {
+        for i := 0; i < 3; i++ {
+            hub.Emit(ActiveChanged{ItemID: uint32(i), Active: true})
+        }
+    }()
+
+    // Give Emit a moment to fill the buffer and block on the second send.
+    time.Sleep(10 * time.Millisecond)
+
+    done := make(chan struct{})
+    go func() {
+        hub.Close()
+        close(done)
+    }()
+
+    select {
+    case <-done:
+    case <-time.After(time.Second):
+        t.Fatal("Close did not return within 1s; a stalled backpressured Emit wedged it")
+    }
+}
+
+// TestSubscribeFiltersByKindAndItemID covers the filtering options Emit
+// relies on, since they decide which events a dropped-on-full consumer
+// would have received.
+func TestSubscribeFiltersByKindAndItemID(t *testing.T) {
+    hub := NewHub()
+    src := hub.Subscribe(WithKinds(KindParameterChanged), WithItemID(1))
+    defer src.Close()
+
+    hub.Emit(ActiveChanged{ItemID: 1, Active: true})
+    hub.Emit(ParameterChanged{ItemID: 2, ParamID: 5})
+    hub.Emit(ParameterChanged{ItemID: 1, ParamID: 5})
+
+    select {
+    case e := <-src.C:
+        pc, ok := e.(ParameterChanged)
+        if !ok || pc.ItemID != 1 {
+            t.Fatalf("got %#v, want ParameterChanged{ItemID: 1, ...}", e)
+        }
+    default:
+        t.Fatal("expected the matching ParameterChanged event, got none")
+    }
+
+    select {
+    case e := <-src.C:
+        t.Fatalf("got unexpected extra event %#v", e)
+    default:
+    }
+}
@@ -0,0 +1,257 @@
+/*
+ * Copyright (C) 2020 Nicolas SCHWARTZ
+ *
+ * This library is free software; you can redistribute it and/or
+ * modify it under the terms of the GNU Lesser General Public
+ * License as published by the Free Software Foundation; either
+ * version 2 of the License, or (at your option) any later version.
+ *
+ * This library is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the GNU
+ * Lesser General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public
+ * License along with this library; if not, write to the Free Software
+ * Foundation, Inc., 51 Franklin St, Fifth Floor, Boston, MA 02110-1301, USA
+ */
+
+package message
+
+import "encoding/binary"
+import "fmt"
+
+import "github.com/StarAurryon/lpedit-lib/model/pod"
+
+// This file is the symmetric counterpart to parse.go: instead of
+// decoding a Message's bytes into a *pod.Pod, each Build... constructor
+// encodes a *pod.Pod (or a piece of it) into the exact byte layout the
+// matching Parse method in parse.go expects. It lets the library write
+// patches back to the device and synthesize test fixtures without
+// hand-rolled byte slices. Offsets are shared with parse.go's consts.
+
+func putUint32(data []byte, offset int, v uint32) {
+    binary.LittleEndian.PutUint32(data[offset:offset+4], v)
+}
+
+// BuildActiveChange encodes an ActiveChange message toggling item id.
+func BuildActiveChange(id uint32, active bool) ActiveChange {
+    data := make([]byte, offsetActive+4)
+    putUint32(data, offsetItemID, id)
+    var v uint32
+    if active {
+        v = 1
+    }
+    putUint32(data, offsetActive, v)
+    return ActiveChange{Message{data: data}}
+}
+
+// buildParameterChange encodes a parameter-change message body shared
+// by ParameterChange, ParameterChangeMin and ParameterChangeMax.
+func buildParameterChange(itemID, paramID uint32, value [4]byte) []byte {
+    data := make([]byte, offsetParamValue+4)
+    putUint32(data, offsetItemID, itemID)
+    putUint32(data, offsetParamID, paramID)
+    copy(data[offsetParamValue:], value[:])
+    return data
+}
+
+// BuildParameterChange encodes a ParameterChange message setting the
+// current value of paramID on item itemID.
+func BuildParameterChange(itemID, paramID uint32, value [4]byte) ParameterChange {
+    return ParameterChange{Message{data: buildParameterChange(itemID, paramID, value)}}
+}
+
+// BuildParameterChangeMin encodes a ParameterChangeMin message.
+func BuildParameterChangeMin(itemID, paramID uint32, value [4]byte) ParameterChangeMin {
+    return ParameterChangeMin{Message{data: buildParameterChange(itemID, paramID, value)}}
+}
+
+// BuildParameterChangeMax encodes a ParameterChangeMax message.
+func BuildParameterChangeMax(itemID, paramID uint32, value [4]byte) ParameterChangeMax {
+    return ParameterChangeMax{Message{data: buildParameterChange(itemID, paramID, value)}}
+}
+
+// BuildTypeChange encodes a TypeChange message swapping item id to a
+// new model ptype.
+func BuildTypeChange(id, ptype uint32) TypeChange {
+    data := make([]byte, offsetActive+4)
+    putUint32(data, offsetItemID, id)
+    putUint32(data, offsetActive, ptype)
+    return TypeChange{Message{data: data}}
+}
+
+// BuildPresetChange encodes a PresetChange message selecting preset idx.
+func BuildPresetChange(idx uint8) PresetChange {
+    data := make([]byte, offsetPresetName+1)
+    data[offsetPresetName] = idx
+    return PresetChange{Message{data: data}}
+}
+
+// BuildSetChange encodes a SetChange message selecting set idx.
+func BuildSetChange(idx uint8) SetChange {
+    data := make([]byte, offsetPresetName+1)
+    data[offsetPresetName] = idx
+    return SetChange{Message{data: data}}
+}
+
+// BuildPresetLoad encodes a full PresetLoad message from preset,
+// the exact inverse of PresetLoad.Parse.
+func BuildPresetLoad(preset *pod.Preset) (PresetLoad, error) {
+    pbiOrder := []uint32{0, 2, 1, 3, 4, 5, 6, 7, 8, 9, 10, 11}
+    // The pedalboard items only span the first 48 + 12*256 bytes; DT,
+    // cab and setup parameters live further out in the message, up to
+    // offsetInput2Source. Size the buffer for the whole message, not
+    // just the pedalboard-item region.
+    size := offsetInput2Source + 1
+    if pbiEnd := offsetPedalBoardItems + len(pbiOrder)*pedalBoardItemStride; pbiEnd > size {
+        size = pbiEnd
+    }
+    data := make([]byte, size)
+
+    name := preset.GetName()
+    copy(data[offsetPresetName:offsetPresetName+presetNameLen], name[:])
+
+    for i, id := range pbiOrder {
+        pbi := preset.GetItem(id)
+        if pbi == nil {
+            return PresetLoad{}, fmt.Errorf("Item ID %d not found", id)
+        }
+        start := offsetPedalBoardItems + i*pedalBoardItemStride
+        end := start + pedalBoardItemStride
+        if err := encodePedalBoardItem(pbi, data[start:end]); err != nil {
+            return PresetLoad{}, err
+        }
+    }
+    if err := encodeDT(preset, data); err != nil {
+        return PresetLoad{}, err
+    }
+    if err := encodeCabs(preset, data); err != nil {
+        return PresetLoad{}, err
+    }
+    if err := encodeSetup(preset, data); err != nil {
+        return PresetLoad{}, err
+    }
+    return PresetLoad{Message{data: data}}, nil
+}
+
+func encodeCabs(p *pod.Preset, data []byte) error {
+    cabs := []*pod.Cab{p.GetCab(0), p.GetCab(1)}
+    offset := [2][2]int{[2]int{offsetCab0ER, offsetCab0Mic}, [2]int{offsetCab1ER, offsetCab1Mic}}
+    parametersID := [2]uint32{pod.CabERID, pod.CabMicID}
+    parametersSize := [2]int{cabERSize, cabMicSize}
+    for i, cab := range cabs {
+        if cab == nil {
+            return fmt.Errorf("Can't find Cab ID %d", i)
+        }
+        for j, pType := range parametersID {
+            param := cab.GetParam(pType)
+            if param == nil {
+                return fmt.Errorf("Can't find Cab ID %d, parameter %d", i, pType)
+            }
+            value := param.GetBinValueCurrent()
+            copy(data[offset[i][j]:offset[i][j]+parametersSize[j]], value[:parametersSize[j]])
+        }
+    }
+    return nil
+}
+
+func encodeDT(p *pod.Preset, data []byte) error {
+    dts := []*pod.DT{p.GetDT(0), p.GetDT(1)}
+    offset := [2][3]int{[3]int{offsetDT0Topology, offsetDT0Class, offsetDT0Mode}, [3]int{offsetDT1Topology, offsetDT1Class, offsetDT1Mode}}
+    for i, dt := range dts {
+        if dt == nil {
+            return fmt.Errorf("Can't find DT ID %d", i)
+        }
+        data[offset[i][0]] = dt.GetBinTopology()
+        data[offset[i][1]] = dt.GetBinClass()
+        data[offset[i][2]] = dt.GetBinMode()
+    }
+    return nil
+}
+
+func encodeSetup(p *pod.Preset, data []byte) error {
+    params := []uint32{pod.PresetGuitarInZ, pod.PresetInput1Source, pod.PresetInput2Source}
+    offset := []int{offsetGuitarInZ, offsetInput1Source, offsetInput2Source}
+    for i, pType := range params {
+        param := p.GetParam(pType)
+        if param == nil {
+            return fmt.Errorf("Can't find PedalBoard Parameter ID %d", pType)
+        }
+        data[offset[i]] = param.GetBinValueCurrent()[0]
+    }
+    return nil
+}
+
+func encodePedalBoardItem(pbi pod.PedalBoardItem, data []byte) error {
+    putUint32(data, offsetPBIType, pbi.GetType())
+
+    binary.LittleEndian.PutUint16(data[offsetPBIPos:offsetPBIPos+2], pbi.GetPos())
+    data[offsetPBIPosType] = pbi.GetPosType()
+
+    if pbi.GetActive() {
+        data[offsetPBIActive] = 1
+    }
+
+    tempos := make([]byte, 0, 2)
+
+    switch pbi.(type) {
+    case *pod.Cab:
+        for i, pType := range []uint32{pod.CabLowCutID, pod.CabResLevelID, pod.CabThumpID, pod.CabDecayID} {
+            start := offsetPBIParams + i*pbiParamStride
+            if err := encodeParameterCab(pbi, data[start:start+pbiParamStride], pType); err != nil {
+                return err
+            }
+        }
+    default:
+        for i := uint16(0); i < pbi.GetParamLen(); i++ {
+            start := offsetPBIParams + int(i)*pbiParamStride
+            tempo, err := encodeParameterNormal(pbi, data[start:start+pbiParamStride], uint32(i))
+            if err != nil {
+                return err
+            }
+            if tempo != nil {
+                tempos = append(tempos, *tempo)
+            }
+        }
+    }
+    copy(data[offsetPBITempo:offsetPBITempo+2], tempos)
+    return nil
+}
+
+func encodeParameterCab(pbi pod.PedalBoardItem, data []byte, paramID uint32) error {
+    param := pbi.GetParam(paramID)
+    if param == nil {
+        return fmt.Errorf("Parameter ID %d does not exist on item type %s", paramID, pbi.GetName())
+    }
+    value := param.GetBinValueCurrent()
+    copy(data[offsetParamValueCur:offsetParamValueCur+4], value[:])
+    return nil
+}
+
+// encodeParameterNormal encodes a single parameter slot and, for a
+// TempoParam, returns the tempo-division byte that PresetLoad.Parse
+// packs separately at offsetPBITempo instead of inside the slot.
+func encodeParameterNormal(pbi pod.PedalBoardItem, data []byte, paramID uint32) (*uint8, error) {
+    param := pbi.GetParam(paramID)
+    if param == nil {
+        return nil, fmt.Errorf("Parameter ID %d does not exist on pod.type %s", paramID, pbi.GetName())
+    }
+    putUint32(data, offsetParamIDField, paramID)
+
+    cur := param.GetBinValueCurrent()
+    copy(data[offsetParamValueCur:offsetParamValueCur+4], cur[:])
+    min := param.GetBinValueMin()
+    copy(data[offsetParamValueMin:offsetParamValueMin+4], min[:])
+    max := param.GetBinValueMax()
+    copy(data[offsetParamValueMax:offsetParamValueMax+4], max[:])
+
+    if _, ok := param.(*pod.TempoParam); ok {
+        // parseParameterNormal treats a tempos byte > 1 as a note
+        // division code overriding the float slot; we always round-trip
+        // the plain float representation, so the division byte is 0.
+        tempo := uint8(0)
+        return &tempo, nil
+    }
+    return nil, nil
+}
@@ -0,0 +1,93 @@
+/*
+ * Copyright (C) 2020 Nicolas SCHWARTZ
+ *
+ * This library is free software; you can redistribute it and/or
+ * modify it under the terms of the GNU Lesser General Public
+ * License as published by the Free Software Foundation; either
+ * version 2 of the License, or (at your option) any later version.
+ *
+ * This library is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the GNU
+ * Lesser General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public
+ * License along with this library; if not, write to the Free Software
+ * Foundation, Inc., 51 Franklin St, Fifth Floor, Boston, MA 02110-1301, USA
+ */
+
+package message
+
+import "testing"
+
+import "github.com/StarAurryon/lpedit-lib/model/pod"
+
+// These round-trip the non-PresetLoad Build* encoders through the
+// matching Parse. BuildPresetLoad is only exercised by preset_test.go's
+// round trip, which checks that it encodes without error but does not
+// parse the result back. These tests would have caught a
+// mismatched offset or field the same way 17a6bad's undersized buffer
+// was caught for PresetLoad.
+
+func TestActiveChangeRoundTrip(t *testing.T) {
+    p := pod.NewPod()
+    _, _, obj := BuildActiveChange(0, true).Parse(p)
+    pbi, ok := obj.(pod.PedalBoardItem)
+    if !ok {
+        t.Fatalf("got %#v, want pod.PedalBoardItem", obj)
+    }
+    if !pbi.GetActive() {
+        t.Fatal("item 0 is not active after BuildActiveChange(0, true)")
+    }
+}
+
+func TestParameterChangeRoundTrip(t *testing.T) {
+    p := pod.NewPod()
+    pbi := p.GetCurrentPreset().GetItem(0)
+    params := pbi.GetParams()
+    if len(params) == 0 {
+        t.Fatal("item 0 has no parameters to round-trip")
+    }
+    paramID := params[0].GetID()
+    want := [4]byte{1, 2, 3, 4}
+
+    _, _, obj := BuildParameterChange(0, paramID, want).Parse(p)
+    param, ok := obj.(pod.Param)
+    if !ok {
+        t.Fatalf("got %#v, want pod.Param", obj)
+    }
+    if got := param.GetBinValueCurrent(); got != want {
+        t.Fatalf("GetBinValueCurrent() = %v, want %v", got, want)
+    }
+}
+
+func TestTypeChangeRoundTrip(t *testing.T) {
+    p := pod.NewPod()
+    pbi := p.GetCurrentPreset().GetItem(0)
+    want := pbi.GetType() + 1
+
+    _, _, obj := BuildTypeChange(0, want).Parse(p)
+    param, ok := obj.(pod.PedalBoardItem)
+    if !ok {
+        t.Fatalf("got %#v, want pod.PedalBoardItem", obj)
+    }
+    if got := param.GetType(); got != want {
+        t.Fatalf("GetType() = %d, want %d", got, want)
+    }
+}
+
+func TestPresetChangeRoundTrip(t *testing.T) {
+    p := pod.NewPod()
+    BuildPresetChange(3).Parse(p)
+    if got := p.GetCurrentPreset(); got == nil {
+        t.Fatal("GetCurrentPreset() returned nil after BuildPresetChange(3)")
+    }
+}
+
+func TestSetChangeRoundTrip(t *testing.T) {
+    p := pod.NewPod()
+    BuildSetChange(2).Parse(p)
+    if got := p.GetCurrentSet(); got == nil {
+        t.Fatal("GetCurrentSet() returned nil after BuildSetChange(2)")
+    }
+}
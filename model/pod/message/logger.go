@@ -0,0 +1,71 @@
+/*
+ * Copyright (C) 2020 Nicolas SCHWARTZ
+ *
+ * This library is free software; you can redistribute it and/or
+ * modify it under the terms of the GNU Lesser General Public
+ * License as published by the Free Software Foundation; either
+ * version 2 of the License, or (at your option) any later version.
+ *
+ * This library is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the GNU
+ * Lesser General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public
+ * License along with this library; if not, write to the Free Software
+ * Foundation, Inc., 51 Franklin St, Fifth Floor, Boston, MA 02110-1301, USA
+ */
+
+package message
+
+import "sync/atomic"
+
+// Logger lets an embedding application route, silence or capture the
+// diagnostics the parsers in parse.go used to send straight to log.Printf.
+// kv is a flat list of alternating key/value pairs, e.g.
+// logger.Warn("parameter not found", "item_id", id, "param_id", paramID).
+type Logger interface {
+    Debug(msg string, kv ...interface{})
+    Info(msg string, kv ...interface{})
+    Warn(msg string, kv ...interface{})
+    Error(msg string, kv ...interface{})
+}
+
+// noopLogger is the default Logger: it discards everything, so using
+// this package costs nothing until SetLogger is called.
+type noopLogger struct{}
+
+func (noopLogger) Debug(string, ...interface{}) {}
+func (noopLogger) Info(string, ...interface{})  {}
+func (noopLogger) Warn(string, ...interface{})  {}
+func (noopLogger) Error(string, ...interface{}) {}
+
+// loggerBox lets logger live in an atomic.Value, which requires every
+// stored value to share a concrete type.
+type loggerBox struct {
+    l Logger
+}
+
+// logger is read by every Parse call, which can run concurrently with
+// a SetLogger call from the embedding application (e.g. wiring up
+// logging after the USB read goroutine is already running), so it's
+// stored behind an atomic.Value rather than a plain package variable.
+var logger atomic.Value
+
+func init() {
+    logger.Store(loggerBox{noopLogger{}})
+}
+
+func getLogger() Logger {
+    return logger.Load().(loggerBox).l
+}
+
+// SetLogger replaces the package-wide Logger used by the parsers in
+// this package. Passing nil restores the default no-op Logger. Safe to
+// call concurrently with in-flight Parse calls.
+func SetLogger(l Logger) {
+    if l == nil {
+        l = noopLogger{}
+    }
+    logger.Store(loggerBox{l})
+}
@@ -21,9 +21,9 @@ package message
 import "encoding/binary"
 import "fmt"
 import "bytes"
-import "log"
 import "reflect"
 
+import "github.com/StarAurryon/lpedit-lib/events"
 import "github.com/StarAurryon/lpedit-lib/model/pod"
 
 type presetPedalPos struct {
@@ -31,8 +31,54 @@ type presetPedalPos struct {
     ptype uint8
 }
 
+// Byte offsets into a Message's payload. These are shared between the
+// parsers below and the encoders in encode.go, so a firmware layout
+// change is a single edit instead of a hunt through magic numbers.
+const (
+    offsetItemID     = 12
+    offsetActive     = 16
+    offsetParamID    = 20
+    offsetParamValue = 24
+
+    offsetPresetName      = 8
+    presetNameLen         = 16
+    offsetPedalBoardItems = 48
+    pedalBoardItemStride  = 256
+
+    offsetPBIType    = 0
+    offsetPBIPos     = 4
+    offsetPBIPosType = 6
+    offsetPBIActive  = 8
+    offsetPBITempo   = 9
+    offsetPBIParams  = 16
+    pbiParamStride   = 20
+
+    offsetParamIDField  = 0
+    offsetParamValueCur = 4
+    offsetParamValueMin = 8
+    offsetParamValueMax = 12
+
+    offsetCab0ER  = 3412
+    offsetCab1ER  = 3420
+    offsetCab0Mic = 4096
+    offsetCab1Mic = 4097
+    cabERSize     = 4
+    cabMicSize    = 1
+
+    offsetDT0Topology = 3124
+    offsetDT0Class    = 3125
+    offsetDT0Mode     = 3126
+    offsetDT1Topology = 3132
+    offsetDT1Class    = 3133
+    offsetDT1Mode     = 3134
+
+    offsetGuitarInZ    = 3546
+    offsetInput1Source = 4102
+    offsetInput2Source = 4103
+)
+
 func (m Message) getPedalBoardItemID() uint32 {
-    return binary.LittleEndian.Uint32(m.data[12:16])
+    return binary.LittleEndian.Uint32(m.data[offsetItemID : offsetItemID+4])
 }
 
 func (m ActiveChange) Parse(p *pod.Pod) (error, int, interface{}) {
@@ -42,34 +88,43 @@ func (m ActiveChange) Parse(p *pod.Pod) (error, int, interface{}) {
         return fmt.Errorf("Item ID %d not found", id), ct.StatusWarning(), nil
     }
     var active bool
-    if binary.LittleEndian.Uint32(m.data[16:]) > 0 {
+    if binary.LittleEndian.Uint32(m.data[offsetActive:]) > 0 {
         active = true
     } else {
         active = false
     }
-    log.Printf("Active change on ID %d status %t\n", id, active)
+    getLogger().Debug("active change", "item_id", id, "active", active)
     pbi.SetActive(active)
+    p.Events().Emit(events.ActiveChanged{ItemID: id, Active: active})
     return nil, ct.StatusActiveChange(), pbi
 }
 
+var parameterChangeField = map[string]events.ParamField{
+    "SetBinValueCurrent": events.ParamCur,
+    "SetBinValueMin":     events.ParamMin,
+    "SetBinValueMax":     events.ParamMax,
+}
+
 func (m *Message) parseParameterChange(paramFunc string, p *pod.Pod) (error, int, interface{}) {
     pid := m.getPedalBoardItemID()
     pbi := p.GetCurrentPreset().GetItem(pid)
     if pbi == nil {
         return fmt.Errorf("Item ID %d not found", pid), ct.StatusWarning(), nil
     }
-    id := binary.LittleEndian.Uint32(m.data[20:24])
+    id := binary.LittleEndian.Uint32(m.data[offsetParamID:offsetParamID+4])
 
     var v [4]byte
-    copy(v[:], m.data[24:])
+    copy(v[:], m.data[offsetParamValue:])
 
     param := pbi.GetParam(id)
     if param == nil {
         return fmt.Errorf("Parameter ID %d not found", id), ct.StatusWarning(), nil
     }
     if err := reflect.ValueOf(param).MethodByName(paramFunc).Interface().(func([4]byte) error)(v); err != nil {
-        log.Printf("TODO: Fix the parameter type on pod.%s, parameter %s, func %s: %s \n", pbi.GetName(), param.GetName(), paramFunc, err)
+        getLogger().Error("can't fix parameter type", "item_id", pid, "param_id", id, "err", err)
+        p.Events().Emit(events.Warning{Message: "unknown parameter type for " + paramFunc, ItemID: pid, ParamID: id, Err: err})
     }
+    p.Events().Emit(events.ParameterChanged{ItemID: pid, ParamID: id, Field: parameterChangeField[paramFunc], Value: v})
     return nil, ct.StatusNone(), param
 }
 
@@ -101,12 +156,13 @@ func (m ParameterTempoChange) Parse(p *pod.Pod) (error, int, interface{}) {
     if param == nil {
         return fmt.Errorf("Parameter ID 0 not found"), ct.StatusWarning(), nil
     }
-    value := float32(binary.LittleEndian.Uint32(m.data[16:]))
+    value := float32(binary.LittleEndian.Uint32(m.data[offsetActive:]))
     binValue := [4]byte{}
     buf := new(bytes.Buffer)
     binary.Write(buf, binary.LittleEndian, value)
     copy(binValue[:], buf.Bytes())
     param.SetBinValueCurrent(binValue)
+    p.Events().Emit(events.ParameterChanged{ItemID: pid, ParamID: 0, Field: events.ParamCur, Value: binValue})
     return nil, ct.StatusParameterChange(), param
 }
 
@@ -120,17 +176,20 @@ func (m ParameterTempoChange2) Parse(p *pod.Pod) (error, int, interface{}) {
     if param == nil {
         return fmt.Errorf("Parameter ID 2 not found"), ct.StatusWarning(), nil
     }
-    value := float32(binary.LittleEndian.Uint32(m.data[16:]))
+    value := float32(binary.LittleEndian.Uint32(m.data[offsetActive:]))
     binValue := [4]byte{}
     buf := new(bytes.Buffer)
     binary.Write(buf, binary.LittleEndian, value)
     copy(binValue[:], buf.Bytes())
     param.SetBinValueCurrent(binValue)
+    p.Events().Emit(events.ParameterChanged{ItemID: pid, ParamID: 2, Field: events.ParamCur, Value: binValue})
     return nil, ct.StatusParameterChange(), param
 }
 
 func (m PresetChange) Parse(p *pod.Pod) (error, int, interface{}) {
-    p.SetCurrentPreset(m.data[8])
+    idx := m.data[offsetPresetName]
+    p.SetCurrentPreset(idx)
+    p.Events().Emit(events.PresetChanged{Index: idx})
     return nil, ct.StatusPresetChange(), p.GetCurrentPreset()
 }
 
@@ -142,43 +201,43 @@ func (m PresetLoad) Parse(p *pod.Pod) (error, int, interface{}) {
     preset := p.GetCurrentPreset()
     pbiOrder := []uint32{0,2,1,3,4,5,6,7,8,9,10,11}
     name := [16]byte{}
-    copy(name[:], m.data[8:24])
+    copy(name[:], m.data[offsetPresetName:offsetPresetName+presetNameLen])
     preset.SetName(name)
 
-    const offset = 48
     var data [256]byte
     for i, id := range pbiOrder {
-        start := offset + (i * 256)
-        end := start + 256
+        start := offsetPedalBoardItems + (i * pedalBoardItemStride)
+        end := start + pedalBoardItemStride
         copy(data[:], m.data[start:end])
-        m.parsePedalBoardItem(preset, data, id)
+        m.parsePedalBoardItem(p, data, id)
     }
     m.parseDT(preset, m.data)
     m.parseCabs(preset, m.data)
     m.parseSetup(preset, m.data)
+    p.Events().Emit(events.PresetLoaded{})
     return nil, ct.StatusPresetLoad(), preset
 }
 
 func (m PresetLoad) parseCabs(p *pod.Preset, data []byte) {
     cabs := []*pod.Cab {p.GetCab(0), p.GetCab(1)}
-    offset := [2][2]int{[2]int{3412, 4096}, [2]int{3420, 4097}}
+    offset := [2][2]int{[2]int{offsetCab0ER, offsetCab0Mic}, [2]int{offsetCab1ER, offsetCab1Mic}}
     parametersID := [2]uint32{pod.CabERID, pod.CabMicID}
     parametersSize := [2]int{4, 1}
     for i, cab := range cabs {
         if cab == nil {
-            log.Printf("Can't find Cab ID %d\n", i)
+            getLogger().Warn("can't find cab", "item_id", i)
             continue
         }
         for j, pType := range parametersID {
             param := cab.GetParam(pType)
             if param == nil {
-                log.Printf("Can't find Cab ID %d, parameter %d\n", i, pType)
+                getLogger().Warn("can't find cab parameter", "item_id", i, "param_id", pType)
                 continue
             }
             value := [4]byte{}
             copy(value[:], data[offset[i][j]:offset[i][j]+parametersSize[j]])
             if err := param.SetBinValueCurrent(value); err != nil {
-                log.Printf("Can't set value Cab ID %d, parameter %d: %s\n", i, pType, err)
+                getLogger().Error("can't set cab parameter value", "item_id", i, "param_id", pType, "offset", offset[i][j], "err", err)
             }
         }
     }
@@ -186,19 +245,19 @@ func (m PresetLoad) parseCabs(p *pod.Preset, data []byte) {
 
 func (m PresetLoad) parseDT(p *pod.Preset, data []byte) {
     dts := []*pod.DT{p.GetDT(0), p.GetDT(1)}
-    offset := [2][3]int{[3]int{3124,3125,3126}, [3]int{3132, 3133, 3134}}
+    offset := [2][3]int{[3]int{offsetDT0Topology, offsetDT0Class, offsetDT0Mode}, [3]int{offsetDT1Topology, offsetDT1Class, offsetDT1Mode}}
     for i, dt := range dts {
         if dt == nil {
-            log.Printf("Can't find DT ID %d\n", i)
+            getLogger().Warn("can't find dt", "item_id", i)
         } else {
             if err := dt.SetBinTopology(data[offset[i][0]]); err != nil {
-                log.Printf("Error while setting DT ID %d Topology: %s\n", i, err)
+                getLogger().Error("error setting dt topology", "item_id", i, "offset", offset[i][0], "err", err)
             }
             if err := dt.SetBinClass(data[offset[i][1]]); err != nil {
-                log.Printf("Error while setting DT ID %d Class: %s\n", i, err)
+                getLogger().Error("error setting dt class", "item_id", i, "offset", offset[i][1], "err", err)
             }
             if err := dt.SetBinMode(data[offset[i][2]]); err != nil {
-                log.Printf("Error while setting DT ID %d Mode: %s\n", i, err)
+                getLogger().Error("error setting dt mode", "item_id", i, "offset", offset[i][2], "err", err)
             }
         }
     }
@@ -207,78 +266,78 @@ func (m PresetLoad) parseDT(p *pod.Preset, data []byte) {
 func (m PresetLoad) parseSetup(p *pod.Preset, data []byte) {
     params := []uint32{pod.PresetGuitarInZ, pod.PresetInput1Source,
         pod.PresetInput2Source}
-    offset := []int{3546, 4102, 4103}
+    offset := []int{offsetGuitarInZ, offsetInput1Source, offsetInput2Source}
     for i, pType := range params {
         param := p.GetParam(pType)
         if param == nil {
-            log.Printf("Can't find PedalBoard Parameter ID %d\n", pType)
+            getLogger().Warn("can't find pedalboard parameter", "param_id", pType)
             continue
         }
         value := [4]byte{}
         value[0] = data[offset[i]]
         if err := param.SetBinValueCurrent(value); err != nil {
-            log.Printf("Error while setting PedalBoard Parameter ID %d: %s\n", pType, err)
+            getLogger().Error("error setting pedalboard parameter", "param_id", pType, "offset", offset[i], "err", err)
         }
     }
 }
 
-func (m PresetLoad) parsePedalBoardItem(p *pod.Preset, data [256]byte, pbiID uint32) {
-    pbi := p.GetItem(pbiID)
+func (m PresetLoad) parsePedalBoardItem(p *pod.Pod, data [256]byte, pbiID uint32) {
+    pbi := p.GetCurrentPreset().GetItem(pbiID)
 
-    pbiType := binary.LittleEndian.Uint32(data[0:4])
+    pbiType := binary.LittleEndian.Uint32(data[offsetPBIType : offsetPBIType+4])
     pbi.SetType(pbiType)
 
-    pos := binary.LittleEndian.Uint16(data[4:6])
-    posType := uint8(data[6])
+    pos := binary.LittleEndian.Uint16(data[offsetPBIPos : offsetPBIPos+2])
+    posType := uint8(data[offsetPBIPosType])
     pbi.SetPosWithoutCheck(pos, posType)
 
     active := false
-    if data[8] == 1 { active = true }
+    if data[offsetPBIActive] == 1 { active = true }
     pbi.SetActive(active)
 
-    tempos := []uint8{data[9], data[10]}
+    tempos := []uint8{data[offsetPBITempo], data[offsetPBITempo+1]}
 
-    const offset = 16
-    var paramData [20]byte
+    var paramData [pbiParamStride]byte
     switch pbi.(type) {
     case *pod.Cab:
         for i, pType := range []uint32{pod.CabLowCutID, pod.CabResLevelID,
             pod.CabThumpID, pod.CabDecayID} {
-            start := offset + (i * 20)
-            end := start + 20
+            start := offsetPBIParams + (i * pbiParamStride)
+            end := start + pbiParamStride
             copy(paramData[:], data[start:end])
-            m.parseParameterCab(pbi, paramData, pType)
+            m.parseParameterCab(p, pbiID, pbi, paramData, pType)
         }
     default:
         for i := uint16(0); i < pbi.GetParamLen(); i++ {
-            start := offset + (i * 20)
-            end := start + 20
+            start := offsetPBIParams + (int(i) * pbiParamStride)
+            end := start + pbiParamStride
             copy(paramData[:], data[start:end])
-            m.parseParameterNormal(pbi, paramData, &tempos)
+            m.parseParameterNormal(p, pbiID, pbi, paramData, &tempos)
         }
     }
 }
 
-func (m PresetLoad) parseParameterCab(pbi pod.PedalBoardItem, data [20]byte, paramID uint32) {
+func (m PresetLoad) parseParameterCab(p *pod.Pod, pbiID uint32, pbi pod.PedalBoardItem, data [20]byte, paramID uint32) {
     param := pbi.GetParam(paramID)
     if param == nil {
-        log.Printf("TODO: Parameter ID %d does not exist on item type %s\n",
-            paramID, pbi.GetName())
+        getLogger().Warn("unknown cab parameter", "item_id", pbiID, "param_id", paramID)
+        p.Events().Emit(events.Warning{Message: "TODO: Fix the parameter type", ItemID: pbiID, ParamID: paramID})
         return
     }
     binValue := [4]byte{}
-    copy(binValue[:], data[4:8])
+    copy(binValue[:], data[offsetParamValueCur:offsetParamValueCur+4])
     if err := param.SetBinValueCurrent(binValue); err != nil {
-        log.Printf("TODO: Fix the parameter type on pod.%s, parameter %s current : %s \n", pbi.GetName(), param.GetName(), err)
+        getLogger().Error("can't set cab item parameter current", "item_id", pbiID, "param_id", paramID, "offset", offsetParamValueCur, "err", err)
+        p.Events().Emit(events.Warning{Message: "TODO: Fix the parameter type", ItemID: pbiID, ParamID: paramID, Err: err})
     }
 }
 
-func (m PresetLoad) parseParameterNormal(pbi pod.PedalBoardItem, data [20]byte, tempos *[]uint8) {
-    paramID := binary.LittleEndian.Uint32(data[0:4])
+func (m PresetLoad) parseParameterNormal(p *pod.Pod, pbiID uint32, pbi pod.PedalBoardItem, data [20]byte, tempos *[]uint8) {
+    paramID := binary.LittleEndian.Uint32(data[offsetParamIDField : offsetParamIDField+4])
     param := pbi.GetParam(paramID)
     if param == nil {
-        log.Printf("TODO: Parameter ID %d does not exist on pod.type %s\n",
-            paramID, pbi.GetName())
+        getLogger().Warn("unknown parameter", "item_id", pbiID, "param_id", paramID)
+        p.Events().Emit(events.Warning{Message: "TODO: Fix the parameter type", ItemID: pbiID, ParamID: paramID})
         return
     }
 
@@ -291,9 +350,9 @@ func (m PresetLoad) parseParameterNormal(pbi pod.PedalBoardItem, data [20]byte,
             v = float32(tempo)
             break
         }
-        binary.Read(bytes.NewReader(data[4:8]), binary.LittleEndian, &v)
+        binary.Read(bytes.NewReader(data[offsetParamValueCur:offsetParamValueCur+4]), binary.LittleEndian, &v)
     default:
-        binary.Read(bytes.NewReader(data[4:8]), binary.LittleEndian, &v)
+        binary.Read(bytes.NewReader(data[offsetParamValueCur:offsetParamValueCur+4]), binary.LittleEndian, &v)
     }
 
     binValue := [4]byte{}
@@ -301,20 +360,25 @@ func (m PresetLoad) parseParameterNormal(pbi pod.PedalBoardItem, data [20]byte,
     binary.Write(buf, binary.LittleEndian, v)
     copy(binValue[:], buf.Bytes())
     if err := param.SetBinValueCurrent(binValue); err != nil {
-        log.Printf("TODO: Fix the parameter type on pod.%s, parameter %s current : %s \n", pbi.GetName(), param.GetName(), err)
+        getLogger().Error("can't set parameter current", "item_id", pbiID, "param_id", paramID, "offset", offsetParamValueCur, "err", err)
+        p.Events().Emit(events.Warning{Message: "TODO: Fix the parameter type", ItemID: pbiID, ParamID: paramID, Err: err})
     }
-    copy(binValue[:], data[8:12])
+    copy(binValue[:], data[offsetParamValueMin:offsetParamValueMin+4])
     if err := param.SetBinValueMin(binValue); err != nil {
-        log.Printf("TODO: Fix the parameter type on pod.%s, parameter %s min: %s \n", pbi.GetName(), param.GetName(), err)
+        getLogger().Error("can't set parameter min", "item_id", pbiID, "param_id", paramID, "offset", offsetParamValueMin, "err", err)
+        p.Events().Emit(events.Warning{Message: "TODO: Fix the parameter type", ItemID: pbiID, ParamID: paramID, Err: err})
     }
-    copy(binValue[:], data[12:16])
+    copy(binValue[:], data[offsetParamValueMax:offsetParamValueMax+4])
     if err := param.SetBinValueMax(binValue); err != nil {
-        log.Printf("TODO: Fix the parameter type on pod.%s, parameter %s max: %s \n", pbi.GetName(), param.GetName(), err)
+        getLogger().Error("can't set parameter max", "item_id", pbiID, "param_id", paramID, "offset", offsetParamValueMax, "err", err)
+        p.Events().Emit(events.Warning{Message: "TODO: Fix the parameter type", ItemID: pbiID, ParamID: paramID, Err: err})
     }
 }
 
 func (m SetChange) Parse(p *pod.Pod) (error, int, interface{}) {
-    p.SetCurrentSet(m.data[8])
+    idx := m.data[offsetPresetName]
+    p.SetCurrentSet(idx)
+    p.Events().Emit(events.SetChanged{Index: idx})
     return nil, ct.StatusSetChange(), p.GetCurrentSet()
 }
 
@@ -331,44 +395,45 @@ func (m SetupChange) Parse(p *pod.Pod) (error, int, interface{}) {
 
     switch setupType {
     case setupMessageCab0ER:
-        return m.parseCab(preset, 0, pod.CabERID, value)
+        return m.parseCab(p, 0, pod.CabERID, value)
     case setupMessageCab1ER:
-        return m.parseCab(preset, 1, pod.CabERID, value)
+        return m.parseCab(p, 1, pod.CabERID, value)
     case setupMessageCab0Mic:
-        return m.parseCab(preset, 0, pod.CabMicID, value)
+        return m.parseCab(p, 0, pod.CabMicID, value)
     case setupMessageCab1Mic:
-        return m.parseCab(preset, 1, pod.CabMicID, value)
+        return m.parseCab(p, 1, pod.CabMicID, value)
     case setupMessageCab0LoCut:
-        return m.parseCab(preset, 0, pod.CabLowCutID, value)
+        return m.parseCab(p, 0, pod.CabLowCutID, value)
     case setupMessageCab1LoCut:
-        return m.parseCab(preset, 1, pod.CabLowCutID, value)
+        return m.parseCab(p, 1, pod.CabLowCutID, value)
     case setupMessageCab0ResLvl:
-        return m.parseCab(preset, 0, pod.CabResLevelID, value)
+        return m.parseCab(p, 0, pod.CabResLevelID, value)
     case setupMessageCab1ResLvl:
-        return m.parseCab(preset, 1, pod.CabResLevelID, value)
+        return m.parseCab(p, 1, pod.CabResLevelID, value)
     case setupMessageCab0Thump:
-        return m.parseCab(preset, 0, pod.CabThumpID, value)
+        return m.parseCab(p, 0, pod.CabThumpID, value)
     case setupMessageCab1Thump:
-        return m.parseCab(preset, 1, pod.CabThumpID, value)
+        return m.parseCab(p, 1, pod.CabThumpID, value)
     case setupMessageCab0Decay:
-        return m.parseCab(preset, 0, pod.CabDecayID, value)
+        return m.parseCab(p, 0, pod.CabDecayID, value)
     case setupMessageCab1Decay:
-        return m.parseCab(preset, 1, pod.CabDecayID, value)
+        return m.parseCab(p, 1, pod.CabDecayID, value)
     case setupMessageInput1Source:
-        return m.parsePedalBoard(preset, pod.PresetInput1Source, value)
+        return m.parsePedalBoard(p, pod.PresetInput1Source, value)
     case setupMessageInput2Source:
-        return m.parsePedalBoard(preset, pod.PresetInput2Source, value)
+        return m.parsePedalBoard(p, pod.PresetInput2Source, value)
     case setupMessageGuitarInZ:
-        return m.parsePedalBoard(preset, pod.PresetGuitarInZ, value)
+        return m.parsePedalBoard(p, pod.PresetGuitarInZ, value)
     case setupMessageTempo:
-        return m.parsePedalBoard(preset, pod.PresetTempo, value)
+        return m.parsePedalBoard(p, pod.PresetTempo, value)
     }
 
     return nil, ct.StatusNone(), nil
 }
 
-func (m SetupChange) parseCab(p *pod.Preset, ID int, paramID uint32, value [4]byte) (error, int, interface{}) {
-    c := p.GetCab(ID)
+func (m SetupChange) parseCab(p *pod.Pod, ID int, paramID uint32, value [4]byte) (error, int, interface{}) {
+    preset := p.GetCurrentPreset()
+    c := preset.GetCab(ID)
     if c == nil {
         return fmt.Errorf("Can't find Cab %d", ID), ct.StatusWarning(), nil
     }
@@ -379,18 +444,24 @@ func (m SetupChange) parseCab(p *pod.Preset, ID int, paramID uint32, value [4]by
     if err := param.SetBinValueCurrent(value); err != nil {
         return fmt.Errorf("Cant set Cab ID %d parameter ID %d value: %s", ID, paramID, err), ct.StatusWarning(), nil
     }
-    return nil, ct.StatusParameterChange(), p
+    p.Events().Emit(events.ParameterChanged{ItemID: uint32(ID), ParamID: paramID, Field: events.ParamCur, Value: value})
+    return nil, ct.StatusParameterChange(), preset
 }
 
-func (m SetupChange) parsePedalBoard(p *pod.Preset, parameterID uint32, value [4]byte) (error, int, interface{}) {
-    param := p.GetParam(parameterID)
+func (m SetupChange) parsePedalBoard(p *pod.Pod, parameterID uint32, value [4]byte) (error, int, interface{}) {
+    preset := p.GetCurrentPreset()
+    param := preset.GetParam(parameterID)
     if param == nil {
         return fmt.Errorf("Can't get PedalBoard parameter ID %d", parameterID), ct.StatusWarning(), nil
     }
     if err := param.SetBinValueCurrent(value); err != nil {
         return fmt.Errorf("Cant set PedalBoard parameter ID %d value: %s", parameterID, err), ct.StatusWarning(), nil
     }
-    return nil, ct.StatusParameterChange(), p
+    // parameterID isn't owned by a pedalboard item, so there's no real
+    // ItemID to report; reusing parameterID lets a subscriber filter on
+    // WithItemID(parameterID) for this one setup parameter specifically.
+    p.Events().Emit(events.ParameterChanged{ItemID: parameterID, ParamID: parameterID, Field: events.ParamCur, Value: value})
+    return nil, ct.StatusParameterChange(), preset
 }
 
 func (m TypeChange) Parse(p *pod.Pod) (error, int, interface{}) {
@@ -399,16 +470,17 @@ func (m TypeChange) Parse(p *pod.Pod) (error, int, interface{}) {
     if param == nil {
         return fmt.Errorf("Item ID %d not found", id), ct.StatusWarning(), nil
     }
-    ptype := binary.LittleEndian.Uint32(m.data[16:])
+    ptype := binary.LittleEndian.Uint32(m.data[offsetActive:])
     if err := param.SetType(ptype); err != nil {
         return err, ct.StatusWarning(), nil
     }
+    p.Events().Emit(events.TypeChanged{ItemID: id, Type: ptype})
     return nil, ct.StatusTypeChange(), param
 }
 
 func (m StatusResponse) Parse(p *pod.Pod) (error, int, interface{}) {
-    status := binary.LittleEndian.Uint32(m.data[12:16])
-    value := binary.LittleEndian.Uint32(m.data[16:])
+    status := binary.LittleEndian.Uint32(m.data[offsetItemID:offsetItemID+4])
+    value := binary.LittleEndian.Uint32(m.data[offsetActive:])
 
     switch status {
     case statusIDPreset:
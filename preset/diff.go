@@ -0,0 +1,117 @@
+/*
+ * Copyright (C) 2020 Nicolas SCHWARTZ
+ *
+ * This library is free software; you can redistribute it and/or
+ * modify it under the terms of the GNU Lesser General Public
+ * License as published by the Free Software Foundation; either
+ * version 2 of the License, or (at your option) any later version.
+ *
+ * This library is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the GNU
+ * Lesser General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public
+ * License along with this library; if not, write to the Free Software
+ * Foundation, Inc., 51 Franklin St, Fifth Floor, Boston, MA 02110-1301, USA
+ */
+
+package preset
+
+import (
+    "bytes"
+    "fmt"
+
+    "github.com/StarAurryon/lpedit-lib/model/pod"
+)
+
+// Diff compares two presets and returns a human-readable line per
+// changed field, in a stable order (name, items, DTs, setup), so it
+// can be printed straight to a terminal or embedded in a commit
+// message when presets are stored in git.
+func Diff(a, b *pod.Preset) ([]string, error) {
+    var changes []string
+
+    if a.GetNameString() != b.GetNameString() {
+        changes = append(changes, fmt.Sprintf("name: %q -> %q", a.GetNameString(), b.GetNameString()))
+    }
+
+    for _, id := range pbiOrder {
+        ai, bi := a.GetItem(id), b.GetItem(id)
+        if ai == nil || bi == nil {
+            return nil, fmt.Errorf("Item ID %d not found", id)
+        }
+        changes = append(changes, diffItem(id, ai, bi)...)
+    }
+
+    for i := 0; i < 2; i++ {
+        ad, bd := a.GetDT(i), b.GetDT(i)
+        if ad == nil || bd == nil {
+            return nil, fmt.Errorf("Can't find DT ID %d", i)
+        }
+        if ad.GetBinTopology() != bd.GetBinTopology() {
+            changes = append(changes, fmt.Sprintf("dt[%d].topology: %d -> %d", i, ad.GetBinTopology(), bd.GetBinTopology()))
+        }
+        if ad.GetBinClass() != bd.GetBinClass() {
+            changes = append(changes, fmt.Sprintf("dt[%d].class: %d -> %d", i, ad.GetBinClass(), bd.GetBinClass()))
+        }
+        if ad.GetBinMode() != bd.GetBinMode() {
+            changes = append(changes, fmt.Sprintf("dt[%d].mode: %d -> %d", i, ad.GetBinMode(), bd.GetBinMode()))
+        }
+    }
+
+    for _, id := range setupParamIDs {
+        ap, bp := a.GetParam(id), b.GetParam(id)
+        if ap == nil || bp == nil {
+            return nil, fmt.Errorf("Can't find PedalBoard Parameter ID %d", id)
+        }
+        changes = append(changes, diffParam("setup."+ap.GetName(), ap, bp)...)
+    }
+
+    return changes, nil
+}
+
+func diffItem(id uint32, a, b pod.PedalBoardItem) []string {
+    var changes []string
+    prefix := fmt.Sprintf("item[%d]", id)
+    if a.GetType() != b.GetType() {
+        changes = append(changes, fmt.Sprintf("%s.type: %d -> %d", prefix, a.GetType(), b.GetType()))
+    }
+    if a.GetActive() != b.GetActive() {
+        changes = append(changes, fmt.Sprintf("%s.active: %t -> %t", prefix, a.GetActive(), b.GetActive()))
+    }
+    if a.GetPos() != b.GetPos() || a.GetPosType() != b.GetPosType() {
+        changes = append(changes, fmt.Sprintf("%s.pos: (%d,%d) -> (%d,%d)", prefix, a.GetPos(), a.GetPosType(), b.GetPos(), b.GetPosType()))
+    }
+
+    aParams, bParams := a.GetParams(), b.GetParams()
+    bByID := make(map[uint32]pod.Param, len(bParams))
+    for _, p := range bParams {
+        bByID[p.GetID()] = p
+    }
+    for _, ap := range aParams {
+        bp, ok := bByID[ap.GetID()]
+        if !ok {
+            continue
+        }
+        changes = append(changes, diffParam(fmt.Sprintf("%s.%s", prefix, ap.GetName()), ap, bp)...)
+    }
+    return changes
+}
+
+func diffParam(label string, a, b pod.Param) []string {
+    var changes []string
+    ac, bc := a.GetBinValueCurrent(), b.GetBinValueCurrent()
+    if !bytes.Equal(ac[:], bc[:]) {
+        changes = append(changes, fmt.Sprintf("%s.cur: % x -> % x", label, ac, bc))
+    }
+    am, bm := a.GetBinValueMin(), b.GetBinValueMin()
+    if !bytes.Equal(am[:], bm[:]) {
+        changes = append(changes, fmt.Sprintf("%s.min: % x -> % x", label, am, bm))
+    }
+    ax, bx := a.GetBinValueMax(), b.GetBinValueMax()
+    if !bytes.Equal(ax[:], bx[:]) {
+        changes = append(changes, fmt.Sprintf("%s.max: % x -> % x", label, ax, bx))
+    }
+    return changes
+}
@@ -0,0 +1,33 @@
+/*
+ * Copyright (C) 2020 Nicolas SCHWARTZ
+ *
+ * This library is free software; you can redistribute it and/or
+ * modify it under the terms of the GNU Lesser General Public
+ * License as published by the Free Software Foundation; either
+ * version 2 of the License, or (at your option) any later version.
+ *
+ * This library is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the GNU
+ * Lesser General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public
+ * License along with this library; if not, write to the Free Software
+ * Foundation, Inc., 51 Franklin St, Fifth Floor, Boston, MA 02110-1301, USA
+ */
+
+package preset
+
+import "github.com/StarAurryon/lpedit-lib/model/pod/message"
+
+// BuildPresetLoad unmarshals a JSON preset document and encodes it as
+// the PresetLoad message the device expects. A CLI only needs to read
+// the file, call this, and write the returned message to the USB
+// endpoint, without hand-rolling the binary layout itself.
+func BuildPresetLoad(data []byte) (message.PresetLoad, error) {
+    p, err := Unmarshal(data)
+    if err != nil {
+        return message.PresetLoad{}, err
+    }
+    return message.BuildPresetLoad(p)
+}
@@ -0,0 +1,226 @@
+/*
+ * Copyright (C) 2020 Nicolas SCHWARTZ
+ *
+ * This library is free software; you can redistribute it and/or
+ * modify it under the terms of the GNU Lesser General Public
+ * License as published by the Free Software Foundation; either
+ * version 2 of the License, or (at your option) any later version.
+ *
+ * This library is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the GNU
+ * Lesser General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public
+ * License along with this library; if not, write to the Free Software
+ * Foundation, Inc., 51 Franklin St, Fifth Floor, Boston, MA 02110-1301, USA
+ */
+
+// Package preset serializes a *pod.Preset to and from a portable,
+// schema-versioned JSON document, so presets can be stored in git,
+// shared, and scripted without the vendor editor's opaque bundle
+// format.
+package preset
+
+import (
+    "encoding/json"
+    "fmt"
+
+    "github.com/StarAurryon/lpedit-lib/model/pod"
+)
+
+// schemaVersion is bumped whenever document's shape changes in a way
+// an older reader can't ignore.
+const schemaVersion = 1
+
+// pbiOrder lists the same item IDs PresetLoad.Parse walks, but not in
+// its wire order (0,2,1,3...): it's the canonical item ID order used to
+// make the JSON document stable and diffable.
+var pbiOrder = []uint32{0, 1, 2, 3, 4, 5, 6, 7, 8, 9, 10, 11}
+
+// setupParamIDs are the pod.Preset-level parameters that aren't owned
+// by any pedalboard item. PresetTempo is deliberately excluded: it's
+// only ever set by the live SetupChange message, not by PresetLoad.Parse
+// or encodeSetup, so round-tripping it here would silently drop
+// whatever value a pushed preset carried.
+var setupParamIDs = []uint32{pod.PresetGuitarInZ, pod.PresetInput1Source,
+    pod.PresetInput2Source}
+
+type document struct {
+    Version int        `json:"version"`
+    Name    string      `json:"name"`
+    Items   []itemDoc   `json:"items"`
+    DTs     []dtDoc     `json:"dts"`
+    Setup   []paramDoc  `json:"setup"`
+}
+
+type paramDoc struct {
+    ID    uint32  `json:"id"`
+    Name  string  `json:"name"`
+    Cur   [4]byte `json:"cur"`
+    Min   [4]byte `json:"min"`
+    Max   [4]byte `json:"max"`
+}
+
+type itemDoc struct {
+    ID      uint32     `json:"id"`
+    Type    uint32     `json:"type"`
+    Pos     uint16     `json:"pos"`
+    PosType uint8      `json:"pos_type"`
+    Active  bool       `json:"active"`
+    Params  []paramDoc `json:"params"`
+}
+
+type dtDoc struct {
+    ID       int   `json:"id"`
+    Topology uint8 `json:"topology"`
+    Class    uint8 `json:"class"`
+    Mode     uint8 `json:"mode"`
+}
+
+func paramToDoc(param pod.Param) paramDoc {
+    return paramDoc{
+        ID:   param.GetID(),
+        Name: param.GetName(),
+        Cur:  param.GetBinValueCurrent(),
+        Min:  param.GetBinValueMin(),
+        Max:  param.GetBinValueMax(),
+    }
+}
+
+func itemToDoc(pbi pod.PedalBoardItem, id uint32) itemDoc {
+    params := pbi.GetParams()
+    doc := itemDoc{
+        ID:      id,
+        Type:    pbi.GetType(),
+        Pos:     pbi.GetPos(),
+        PosType: pbi.GetPosType(),
+        Active:  pbi.GetActive(),
+        Params:  make([]paramDoc, len(params)),
+    }
+    for i, param := range params {
+        doc.Params[i] = paramToDoc(param)
+    }
+    return doc
+}
+
+// Marshal serializes preset's name, per-item type/pos/active/params,
+// DT topology/class/mode and setup parameters (guitar-in Z, input
+// sources) to a schema-versioned JSON document. Tempo is deliberately
+// left out, see setupParamIDs.
+func Marshal(preset *pod.Preset) ([]byte, error) {
+    doc := document{
+        Version: schemaVersion,
+        Name:    preset.GetNameString(),
+    }
+
+    for _, id := range pbiOrder {
+        pbi := preset.GetItem(id)
+        if pbi == nil {
+            return nil, fmt.Errorf("Item ID %d not found", id)
+        }
+        doc.Items = append(doc.Items, itemToDoc(pbi, id))
+    }
+
+    for i := 0; i < 2; i++ {
+        dt := preset.GetDT(i)
+        if dt == nil {
+            return nil, fmt.Errorf("Can't find DT ID %d", i)
+        }
+        doc.DTs = append(doc.DTs, dtDoc{
+            ID:       i,
+            Topology: dt.GetBinTopology(),
+            Class:    dt.GetBinClass(),
+            Mode:     dt.GetBinMode(),
+        })
+    }
+
+    for _, id := range setupParamIDs {
+        param := preset.GetParam(id)
+        if param == nil {
+            return nil, fmt.Errorf("Can't find PedalBoard Parameter ID %d", id)
+        }
+        doc.Setup = append(doc.Setup, paramToDoc(param))
+    }
+
+    return json.MarshalIndent(doc, "", "  ")
+}
+
+// Unmarshal parses a document produced by Marshal back into a fresh
+// *pod.Preset. Items, DTs and parameters in the document that the
+// preset doesn't have are reported as errors rather than silently
+// ignored, since a partially applied preset is worse than a rejected
+// one. Items and parameters the document leaves out are not reported;
+// they keep pod.NewPreset's values.
+func Unmarshal(data []byte) (*pod.Preset, error) {
+    var doc document
+    if err := json.Unmarshal(data, &doc); err != nil {
+        return nil, err
+    }
+    if doc.Version != schemaVersion {
+        return nil, fmt.Errorf("Unsupported preset schema version %d", doc.Version)
+    }
+
+    preset := pod.NewPreset()
+    preset.SetNameString(doc.Name)
+
+    for _, item := range doc.Items {
+        pbi := preset.GetItem(item.ID)
+        if pbi == nil {
+            return nil, fmt.Errorf("Item ID %d not found", item.ID)
+        }
+        pbi.SetType(item.Type)
+        pbi.SetPosWithoutCheck(item.Pos, item.PosType)
+        pbi.SetActive(item.Active)
+        for _, pd := range item.Params {
+            param := pbi.GetParam(pd.ID)
+            if param == nil {
+                return nil, fmt.Errorf("Parameter ID %d not found on item %d", pd.ID, item.ID)
+            }
+            if err := setParamValues(param, pd); err != nil {
+                return nil, err
+            }
+        }
+    }
+
+    for _, dt := range doc.DTs {
+        d := preset.GetDT(dt.ID)
+        if d == nil {
+            return nil, fmt.Errorf("Can't find DT ID %d", dt.ID)
+        }
+        if err := d.SetBinTopology(dt.Topology); err != nil {
+            return nil, err
+        }
+        if err := d.SetBinClass(dt.Class); err != nil {
+            return nil, err
+        }
+        if err := d.SetBinMode(dt.Mode); err != nil {
+            return nil, err
+        }
+    }
+
+    for _, pd := range doc.Setup {
+        param := preset.GetParam(pd.ID)
+        if param == nil {
+            return nil, fmt.Errorf("Can't find PedalBoard Parameter ID %d", pd.ID)
+        }
+        if err := setParamValues(param, pd); err != nil {
+            return nil, err
+        }
+    }
+
+    return preset, nil
+}
+
+func setParamValues(param pod.Param, pd paramDoc) error {
+    if err := param.SetBinValueCurrent(pd.Cur); err != nil {
+        return fmt.Errorf("Parameter %s current: %s", pd.Name, err)
+    }
+    if err := param.SetBinValueMin(pd.Min); err != nil {
+        return fmt.Errorf("Parameter %s min: %s", pd.Name, err)
+    }
+    if err := param.SetBinValueMax(pd.Max); err != nil {
+        return fmt.Errorf("Parameter %s max: %s", pd.Name, err)
+    }
+    return nil
+}
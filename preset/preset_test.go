@@ -0,0 +1,55 @@
+/*
+ * Copyright (C) 2020 Nicolas SCHWARTZ
+ *
+ * This library is free software; you can redistribute it and/or
+ * modify it under the terms of the GNU Lesser General Public
+ * License as published by the Free Software Foundation; either
+ * version 2 of the License, or (at your option) any later version.
+ *
+ * This library is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the GNU
+ * Lesser General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public
+ * License along with this library; if not, write to the Free Software
+ * Foundation, Inc., 51 Franklin St, Fifth Floor, Boston, MA 02110-1301, USA
+ */
+
+package preset
+
+import "testing"
+
+import "github.com/StarAurryon/lpedit-lib/model/pod"
+import "github.com/StarAurryon/lpedit-lib/model/pod/message"
+
+// TestRoundTrip exercises the path this package exists for: marshal a
+// preset to JSON, unmarshal it back, confirm Diff sees no change, then
+// re-encode it as a PresetLoad message without error. This is the path
+// that used to panic in BuildPresetLoad. The setup tempo parameter is
+// not part of the document (see setupParamIDs), so it is not covered.
+func TestRoundTrip(t *testing.T) {
+    preset := pod.NewPreset()
+
+    data, err := Marshal(preset)
+    if err != nil {
+        t.Fatalf("Marshal: %s", err)
+    }
+
+    got, err := Unmarshal(data)
+    if err != nil {
+        t.Fatalf("Unmarshal: %s", err)
+    }
+
+    changes, err := Diff(preset, got)
+    if err != nil {
+        t.Fatalf("Diff: %s", err)
+    }
+    if len(changes) != 0 {
+        t.Errorf("Diff found %d change(s) after a round-trip, want 0: %v", len(changes), changes)
+    }
+
+    if _, err := message.BuildPresetLoad(got); err != nil {
+        t.Fatalf("BuildPresetLoad: %s", err)
+    }
+}